@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"github.com/urfave/cli"
+	"golang.org/x/net/context"
+	goyaml "gopkg.in/yaml.v2"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+)
+
+const (
+	kindPodSandbox = "PodSandbox"
+	kindContainer  = "Container"
+)
+
+// podSandboxManifest is a PodSandboxConfig document from a `hc apply`
+// manifest. Its metadata.name doubles as the idempotency key: re-applying
+// the manifest reuses the already-running sandbox of the same name instead
+// of creating a duplicate.
+type podSandboxManifest struct {
+	Kind string `json:"kind"`
+	pb.PodSandboxConfig
+}
+
+// containerManifest is a ContainerConfig document. podSandboxName links it
+// to the PodSandbox document it should be created in, since CreateContainer
+// needs a sandbox ID that a declarative manifest can't know in advance.
+type containerManifest struct {
+	Kind           string `json:"kind"`
+	PodSandboxName string `json:"podSandboxName"`
+	pb.ContainerConfig
+}
+
+var hcApplyCommand = cli.Command{
+	Name:      "apply",
+	Usage:     "Reconcile running pods/containers against a declarative YAML manifest",
+	ArgsUsage: "MANIFEST",
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "prune",
+			Usage: "Stop and remove running pods/containers whose name no longer appears in MANIFEST",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		if context.NArg() != 1 {
+			return fmt.Errorf("apply takes exactly one manifest file argument")
+		}
+		var err error
+		if err = getRuntimeClient(context); err != nil {
+			return err
+		}
+		if err = getImageClient(context); err != nil {
+			return err
+		}
+		return applyManifest(runtimeClient, context.Args().Get(0), context.Bool("prune"))
+	},
+}
+
+func applyManifest(client pb.RuntimeServiceClient, manifestPath string, prune bool) error {
+	pods, containers, err := parseManifest(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	existingPods, err := client.ListPodSandbox(context.Background(), &pb.ListPodSandboxRequest{})
+	if err != nil {
+		return err
+	}
+	existingPodByName := map[string]*pb.PodSandbox{}
+	for _, p := range existingPods.Items {
+		existingPodByName[p.Metadata.Name] = p
+	}
+
+	existingContainers, err := client.ListContainers(context.Background(), &pb.ListContainersRequest{})
+	if err != nil {
+		return err
+	}
+	existingContainerByName := map[string]*pb.Container{}
+	for _, c := range existingContainers.Containers {
+		existingContainerByName[c.Metadata.Name] = c
+	}
+
+	podSandboxIDByName := map[string]string{}
+	for _, p := range pods {
+		if existing, ok := existingPodByName[p.Metadata.Name]; ok {
+			podSandboxIDByName[p.Metadata.Name] = existing.Id
+			delete(existingPodByName, p.Metadata.Name)
+			continue
+		}
+		cfg := p.PodSandboxConfig
+		resp, err := client.RunPodSandbox(context.Background(), &pb.RunPodSandboxRequest{Config: &cfg})
+		if err != nil {
+			return fmt.Errorf("running pod sandbox %q: %v", p.Metadata.Name, err)
+		}
+		fmt.Printf("pod/%s created (%s)\n", p.Metadata.Name, getTruncatedID(resp.PodSandboxId, ""))
+		podSandboxIDByName[p.Metadata.Name] = resp.PodSandboxId
+	}
+
+	for _, c := range containers {
+		if _, ok := existingContainerByName[c.Metadata.Name]; ok {
+			delete(existingContainerByName, c.Metadata.Name)
+			continue
+		}
+		podSandboxID, ok := podSandboxIDByName[c.PodSandboxName]
+		if !ok {
+			return fmt.Errorf("container %q references unknown podSandboxName %q", c.Metadata.Name, c.PodSandboxName)
+		}
+		containerCfg := c.ContainerConfig
+		createResp, err := client.CreateContainer(context.Background(), &pb.CreateContainerRequest{
+			PodSandboxId:  podSandboxID,
+			Config:        &containerCfg,
+			SandboxConfig: findPodSandboxConfig(pods, c.PodSandboxName),
+		})
+		if err != nil {
+			return fmt.Errorf("creating container %q: %v", c.Metadata.Name, err)
+		}
+		if _, err := client.StartContainer(context.Background(), &pb.StartContainerRequest{ContainerId: createResp.ContainerId}); err != nil {
+			return fmt.Errorf("starting container %q: %v", c.Metadata.Name, err)
+		}
+		fmt.Printf("container/%s created (%s)\n", c.Metadata.Name, getTruncatedID(createResp.ContainerId, ""))
+	}
+
+	if !prune {
+		return nil
+	}
+
+	for name, c := range existingContainerByName {
+		if err := stopAndRemoveContainer(client, c.Id); err != nil {
+			return fmt.Errorf("pruning container %q: %v", name, err)
+		}
+		fmt.Printf("container/%s pruned\n", name)
+	}
+	for name, p := range existingPodByName {
+		if err := stopAndRemovePodSandbox(client, p.Id); err != nil {
+			return fmt.Errorf("pruning pod %q: %v", name, err)
+		}
+		fmt.Printf("pod/%s pruned\n", name)
+	}
+	return nil
+}
+
+func parseManifest(path string) ([]podSandboxManifest, []containerManifest, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	docs, err := splitYAMLDocuments(data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var pods []podSandboxManifest
+	var containers []containerManifest
+	for _, doc := range docs {
+		var probe struct {
+			Kind string `json:"kind"`
+		}
+		if err := yaml.Unmarshal(doc, &probe); err != nil {
+			return nil, nil, fmt.Errorf("parsing manifest document: %v", err)
+		}
+		switch probe.Kind {
+		case kindPodSandbox:
+			var p podSandboxManifest
+			if err := yaml.Unmarshal(doc, &p); err != nil {
+				return nil, nil, fmt.Errorf("parsing PodSandbox document: %v", err)
+			}
+			if p.Metadata == nil || p.Metadata.Name == "" {
+				return nil, nil, fmt.Errorf("PodSandbox document is missing metadata.name")
+			}
+			pods = append(pods, p)
+		case kindContainer:
+			var c containerManifest
+			if err := yaml.Unmarshal(doc, &c); err != nil {
+				return nil, nil, fmt.Errorf("parsing Container document: %v", err)
+			}
+			if c.Metadata == nil || c.Metadata.Name == "" {
+				return nil, nil, fmt.Errorf("Container document is missing metadata.name")
+			}
+			containers = append(containers, c)
+		default:
+			return nil, nil, fmt.Errorf("unknown manifest kind %q (want %q or %q)", probe.Kind, kindPodSandbox, kindContainer)
+		}
+	}
+	return pods, containers, nil
+}
+
+// splitYAMLDocuments splits a multi-document YAML file into individual
+// documents. It decodes with a real YAML stream decoder rather than
+// splitting on the literal "---" substring, since a block scalar value can
+// legitimately contain a line starting with "---" and a naive string split
+// would mangle it instead of erroring clearly. ghodss/yaml only understands
+// one document at a time, so each document is re-marshaled back to YAML for
+// parseManifest to hand to it.
+func splitYAMLDocuments(data []byte) ([][]byte, error) {
+	decoder := goyaml.NewDecoder(bytes.NewReader(data))
+	var docs [][]byte
+	for {
+		var doc goyaml.MapSlice
+		if err := decoder.Decode(&doc); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("parsing manifest: %v", err)
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		b, err := goyaml.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("parsing manifest: %v", err)
+		}
+		docs = append(docs, b)
+	}
+	return docs, nil
+}
+
+func findPodSandboxConfig(pods []podSandboxManifest, name string) *pb.PodSandboxConfig {
+	for _, p := range pods {
+		if p.Metadata.Name == name {
+			cfg := p.PodSandboxConfig
+			return &cfg
+		}
+	}
+	return nil
+}
+
+func stopAndRemoveContainer(client pb.RuntimeServiceClient, id string) error {
+	if _, err := client.StopContainer(context.Background(), &pb.StopContainerRequest{ContainerId: id}); err != nil {
+		return err
+	}
+	_, err := client.RemoveContainer(context.Background(), &pb.RemoveContainerRequest{ContainerId: id})
+	return err
+}
+
+func stopAndRemovePodSandbox(client pb.RuntimeServiceClient, id string) error {
+	if _, err := client.StopPodSandbox(context.Background(), &pb.StopPodSandboxRequest{PodSandboxId: id}); err != nil {
+		return err
+	}
+	_, err := client.RemovePodSandbox(context.Background(), &pb.RemovePodSandboxRequest{PodSandboxId: id})
+	return err
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/context"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+)
+
+// buildContainerFilter translates the --all/--state flags that hc ls, hc
+// pids and hc stats all expose into a CRI ContainerFilter. Centralizing it
+// means --state gets validated identically by every caller instead of each
+// command carrying its own copy of the created/running/exited/unknown
+// switch.
+func buildContainerFilter(all bool, state string) (*pb.ContainerFilter, error) {
+	filter := &pb.ContainerFilter{}
+	st := &pb.ContainerStateValue{}
+	if !all {
+		st.State = pb.ContainerState_CONTAINER_RUNNING
+		filter.State = st
+	}
+	if state != "" {
+		switch strings.ToLower(state) {
+		case "created":
+			st.State = pb.ContainerState_CONTAINER_CREATED
+		case "running":
+			st.State = pb.ContainerState_CONTAINER_RUNNING
+		case "exited":
+			st.State = pb.ContainerState_CONTAINER_EXITED
+		case "unknown":
+			st.State = pb.ContainerState_CONTAINER_UNKNOWN
+		default:
+			return nil, fmt.Errorf("--state should be one of created, running, exited or unknown")
+		}
+		filter.State = st
+	}
+	return filter, nil
+}
+
+// listContainers runs the shared --all/--state/--name query hc ls, hc pids
+// and hc stats all start from, so they can't silently drift apart on what a
+// bad --state value does (the CRI filter is validated up front here, not
+// discovered mid-watch-loop in whichever command happens to hit it first).
+func listContainers(client pb.RuntimeServiceClient, all bool, state, nameRegexp string) ([]*pb.Container, error) {
+	filter, err := buildContainerFilter(all, state)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := client.ListContainers(context.Background(), &pb.ListContainersRequest{Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+
+	containers := make([]*pb.Container, 0, len(r.Containers))
+	for _, c := range r.Containers {
+		if matchesRegex(nameRegexp, c.Metadata.Name) {
+			containers = append(containers, c)
+		}
+	}
+	return containers, nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+)
+
+// containerPredicate is a single --filter term evaluated against a
+// container once the CRI-native ListContainersRequest.Filter has already
+// narrowed the result set. This lets `hc ls` express queries the CRI
+// filter can't, such as a label value, an image reference, or a mount path.
+type containerPredicate func(c *pb.Container, mountPoint string) bool
+
+// parseFilters turns the repeatable --filter key=value flags (docker/podman
+// conventions) into a list of predicates that are AND-composed: a container
+// must satisfy every one of them to be included.
+func parseFilters(filters []string) ([]containerPredicate, error) {
+	var predicates []containerPredicate
+	for _, f := range filters {
+		key, value, ok := splitKeyValue(f)
+		if !ok {
+			return nil, fmt.Errorf("invalid --filter %q, want key=value", f)
+		}
+		predicate, err := newContainerPredicate(key, value)
+		if err != nil {
+			return nil, err
+		}
+		predicates = append(predicates, predicate)
+	}
+	return predicates, nil
+}
+
+func splitKeyValue(s string) (key, value string, ok bool) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+func newContainerPredicate(key, value string) (containerPredicate, error) {
+	switch key {
+	case "label":
+		labelKey, labelValue, ok := splitKeyValue(value)
+		if !ok {
+			return nil, fmt.Errorf("invalid --filter label=%s, want label=k=v", value)
+		}
+		return func(c *pb.Container, _ string) bool {
+			return c.Labels[labelKey] == labelValue
+		}, nil
+	case "image", "ancestor":
+		// The CRI doesn't expose image layer lineage, so "ancestor" can only
+		// match a container's own image rather than any image in its history.
+		return func(c *pb.Container, _ string) bool {
+			return c.GetImage().GetImage() == value
+		}, nil
+	case "sandbox":
+		return func(c *pb.Container, _ string) bool {
+			return c.PodSandboxId == value
+		}, nil
+	case "created-before":
+		age, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --filter created-before=%s: %v", value, err)
+		}
+		cutoff := time.Now().Add(-age)
+		return func(c *pb.Container, _ string) bool {
+			return time.Unix(0, c.CreatedAt).Before(cutoff)
+		}, nil
+	case "mount":
+		wantPrefix := strings.TrimSuffix(value, "/") + "/"
+		return func(_ *pb.Container, mountPoint string) bool {
+			return mountPoint == value || strings.HasPrefix(mountPoint, wantPrefix)
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported --filter key %q", key)
+	}
+}
+
+func matchesFilters(predicates []containerPredicate, c *pb.Container, mountPoint string) bool {
+	for _, p := range predicates {
+		if !p(c, mountPoint) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchesPID implements the --pid flag shared by `hc ls`, `hc pids` and
+// `hc stats`. An empty pidFilter matches everything; otherwise the
+// container's own pid must match exactly. It deliberately doesn't short
+// circuit the caller's scan: when PID namespaces are shared, more than one
+// container can report the same pid, and all of them should be kept.
+func matchesPID(pidFilter, containerPID string) bool {
+	return pidFilter == "" || pidFilter == containerPID
+}
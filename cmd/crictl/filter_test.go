@@ -0,0 +1,135 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+)
+
+func TestMatchesPID(t *testing.T) {
+	cases := []struct {
+		name      string
+		pidFilter string
+		pid       string
+		want      bool
+	}{
+		{"no filter matches anything", "", "1234", true},
+		{"exact match", "1234", "1234", true},
+		{"mismatch", "1234", "5678", false},
+		// The whole point of matchesPID is that callers keep scanning instead
+		// of stopping at the first hit, so two containers sharing a
+		// namespaced PID view must both satisfy the same filter value.
+		{"second container sharing the pid also matches", "1234", "1234", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := matchesPID(c.pidFilter, c.pid); got != c.want {
+				t.Errorf("matchesPID(%q, %q) = %v, want %v", c.pidFilter, c.pid, got, c.want)
+			}
+		})
+	}
+}
+
+func TestNewContainerPredicateLabel(t *testing.T) {
+	predicate, err := newContainerPredicate("label", "tier=frontend")
+	if err != nil {
+		t.Fatalf("newContainerPredicate: %v", err)
+	}
+	c := &pb.Container{Labels: map[string]string{"tier": "frontend"}}
+	if !predicate(c, "") {
+		t.Errorf("expected label=tier=frontend to match container with that label")
+	}
+	c.Labels["tier"] = "backend"
+	if predicate(c, "") {
+		t.Errorf("expected label=tier=frontend not to match container labeled tier=backend")
+	}
+}
+
+func TestNewContainerPredicateImage(t *testing.T) {
+	predicate, err := newContainerPredicate("ancestor", "busybox:latest")
+	if err != nil {
+		t.Fatalf("newContainerPredicate: %v", err)
+	}
+	c := &pb.Container{Image: &pb.ImageSpec{Image: "busybox:latest"}}
+	if !predicate(c, "") {
+		t.Errorf("expected ancestor=busybox:latest to match container with that image")
+	}
+	c.Image.Image = "nginx:latest"
+	if predicate(c, "") {
+		t.Errorf("expected ancestor=busybox:latest not to match container with nginx:latest")
+	}
+}
+
+func TestNewContainerPredicateSandbox(t *testing.T) {
+	predicate, err := newContainerPredicate("sandbox", "sandbox-id-1")
+	if err != nil {
+		t.Fatalf("newContainerPredicate: %v", err)
+	}
+	c := &pb.Container{PodSandboxId: "sandbox-id-1"}
+	if !predicate(c, "") {
+		t.Errorf("expected sandbox=sandbox-id-1 to match container in that sandbox")
+	}
+	c.PodSandboxId = "sandbox-id-2"
+	if predicate(c, "") {
+		t.Errorf("expected sandbox=sandbox-id-1 not to match container in sandbox-id-2")
+	}
+}
+
+func TestNewContainerPredicateCreatedBefore(t *testing.T) {
+	predicate, err := newContainerPredicate("created-before", "1h")
+	if err != nil {
+		t.Fatalf("newContainerPredicate: %v", err)
+	}
+	old := &pb.Container{CreatedAt: time.Now().Add(-2 * time.Hour).UnixNano()}
+	if !predicate(old, "") {
+		t.Errorf("expected a container created 2h ago to match created-before=1h")
+	}
+	recent := &pb.Container{CreatedAt: time.Now().UnixNano()}
+	if predicate(recent, "") {
+		t.Errorf("expected a container created just now not to match created-before=1h")
+	}
+}
+
+func TestNewContainerPredicateMount(t *testing.T) {
+	predicate, err := newContainerPredicate("mount", "/var/lib/containers/storage/overlay/abc")
+	if err != nil {
+		t.Fatalf("newContainerPredicate: %v", err)
+	}
+	if !predicate(&pb.Container{}, "/var/lib/containers/storage/overlay/abc") {
+		t.Errorf("expected exact mountpoint match")
+	}
+	if !predicate(&pb.Container{}, "/var/lib/containers/storage/overlay/abc/merged") {
+		t.Errorf("expected a mountpoint under the filter path to match")
+	}
+	if predicate(&pb.Container{}, "/var/lib/containers/storage/overlay/xyz") {
+		t.Errorf("expected a different mountpoint not to match")
+	}
+}
+
+func TestParseFiltersInvalid(t *testing.T) {
+	if _, err := parseFilters([]string{"nokeyvalue"}); err == nil {
+		t.Errorf("expected an error for a --filter without key=value")
+	}
+	if _, err := parseFilters([]string{"bogus=1"}); err == nil {
+		t.Errorf("expected an error for an unsupported --filter key")
+	}
+}
+
+func TestMatchesFiltersANDsAllPredicates(t *testing.T) {
+	predicates, err := parseFilters([]string{"label=tier=frontend", "sandbox=sandbox-id-1"})
+	if err != nil {
+		t.Fatalf("parseFilters: %v", err)
+	}
+	c := &pb.Container{
+		Labels:       map[string]string{"tier": "frontend"},
+		PodSandboxId: "sandbox-id-1",
+	}
+	if !matchesFilters(predicates, c, "") {
+		t.Errorf("expected a container satisfying both filters to match")
+	}
+	c.PodSandboxId = "sandbox-id-2"
+	if matchesFilters(predicates, c, "") {
+		t.Errorf("expected a container satisfying only one filter not to match")
+	}
+}
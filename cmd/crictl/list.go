@@ -9,21 +9,12 @@ import (
 	"fmt"
 	"github.com/docker/go-units"
 	"github.com/ghodss/yaml"
-	"github.com/tidwall/gjson"
 	"github.com/urfave/cli"
-	"golang.org/x/net/context"
-	"io/ioutil"
 	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
-	"log"
-	"path/filepath"
-	"strings"
+	"strconv"
 	"time"
 )
 
-const (
-	STORAGEROOT = "/etc/containers/storage.conf"
-)
-
 type hcListMessage struct {
 	ContainerId string
 	CTM         string
@@ -63,12 +54,24 @@ var hcListCommand = cli.Command{
 		},
 		cli.StringFlag{
 			Name:  "output, o",
-			Usage: "Output format, One of: json|yaml|table",
+			Usage: "Output format, one of: json|yaml|table|go-template|go-template-file=<path>|jsonpath=<expr>",
+		},
+		cli.StringFlag{
+			Name:  "template",
+			Usage: "Template string used with --output go-template",
 		},
 		cli.BoolFlag{
 			Name:  "no-trunc",
 			Usage: "Show output without truncating the ID",
 		},
+		cli.StringFlag{
+			Name:  "runtime-backend",
+			Usage: "Container state backend to use, one of: crio|containerd (default: auto-detected from --runtime-endpoint)",
+		},
+		cli.StringSliceFlag{
+			Name:  "filter, f",
+			Usage: "Filter output based on conditions given, AND-composed with any other --filter. One or more of: label=k=v|image=<ref>|sandbox=<id>|ancestor=<image>|created-before=<duration>|mount=<path>",
+		},
 	},
 	Action: func(context *cli.Context) error {
 		var err error
@@ -79,12 +82,16 @@ var hcListCommand = cli.Command{
 			return err
 		}
 		opts := hcListOptions{
-			all:        context.Bool("all"),
-			pid:        context.String("pid"),
-			state:      context.String("state"),
-			nameRegexp: context.String("name"),
-			noTrunc:    context.Bool("no-trunc"),
-			output:     context.String("output"),
+			all:             context.Bool("all"),
+			pid:             context.String("pid"),
+			state:           context.String("state"),
+			nameRegexp:      context.String("name"),
+			noTrunc:         context.Bool("no-trunc"),
+			output:          context.String("output"),
+			template:        context.String("template"),
+			runtimeBackend:  context.String("runtime-backend"),
+			runtimeEndpoint: context.GlobalString("runtime-endpoint"),
+			filters:         context.StringSlice("filter"),
 		}
 
 		if err = hcListContainers(runtimeClient, opts); err != nil {
@@ -96,71 +103,51 @@ var hcListCommand = cli.Command{
 }
 
 func hcListContainers(client pb.RuntimeServiceClient, opts hcListOptions) error {
-	filter := &pb.ContainerFilter{}
-	st := &pb.ContainerStateValue{}
-	if !opts.all {
-		st.State = pb.ContainerState_CONTAINER_RUNNING
-		filter.State = st
-	}
-	if opts.state != "" {
-		st.State = pb.ContainerState_CONTAINER_UNKNOWN
-		switch strings.ToLower(opts.state) {
-		case "created":
-			st.State = pb.ContainerState_CONTAINER_CREATED
-			filter.State = st
-		case "running":
-			st.State = pb.ContainerState_CONTAINER_RUNNING
-			filter.State = st
-		case "exited":
-			st.State = pb.ContainerState_CONTAINER_EXITED
-			filter.State = st
-		case "unknown":
-			st.State = pb.ContainerState_CONTAINER_UNKNOWN
-			filter.State = st
-		default:
-			log.Fatalf("--state should be one of created, running, exited or unknown")
-		}
+	containers, err := listContainers(client, opts.all, opts.state, opts.nameRegexp)
+	if err != nil {
+		return err
 	}
 
-	request := &pb.ListContainersRequest{
-		Filter: filter,
-	}
-	r, err := client.ListContainers(context.Background(), request)
+	reader, err := newContainerStateReader(opts.runtimeBackend, opts.runtimeEndpoint)
 	if err != nil {
 		return err
 	}
 
-	storageOpts := StoreOptions{}
-	ReloadConfigurationFile(STORAGEROOT, &storageOpts)
-
-	root := filepath.Join(storageOpts.GraphRoot, storageOpts.GraphDriverName+"-containers")
+	predicates, err := parseFilters(opts.filters)
+	if err != nil {
+		return err
+	}
 
 	result := hcListResult{}
-	for _, c := range r.Containers {
-		if !matchesRegex(opts.nameRegexp, c.Metadata.Name) {
-			continue
-		}
+	for _, c := range containers {
 		createdAt := time.Unix(0, c.CreatedAt)
 		ctm := units.HumanDuration(time.Now().UTC().Sub(createdAt)) + " ago"
 		id := c.Id
-		configRoot := filepath.Join(root, id, "userdata", "config.json")
-		stateRoot := filepath.Join(root, id, "userdata", "state.json")
-		configJson, err := ioutil.ReadFile(configRoot)
+		containerPID, err := reader.PID(id)
 		if err != nil {
 			return err
 		}
-		stateJson, err := ioutil.ReadFile(stateRoot)
+		mountPoint, err := reader.RootfsMountpoint(id)
 		if err != nil {
 			return err
 		}
-		mountPoint := gjson.Get(string(configJson), "root.path").String()
-		pid := gjson.Get(string(stateJson), "pid").String()
-		IP := gjson.Get(string(stateJson), "annotations.io\\.kubernetes\\.cri-o\\.IP").String()
-		fmt.Println(opts.noTrunc)
+		IP, err := reader.PodIP(c.PodSandboxId)
+		if err != nil {
+			return err
+		}
+		pid := strconv.Itoa(containerPID)
+
+		if !matchesPID(opts.pid, pid) {
+			continue
+		}
+		if !matchesFilters(predicates, c, mountPoint) {
+			continue
+		}
+
 		if !opts.noTrunc {
 			id = getTruncatedID(id, "")
 		}
-		message := hcListMessage{
+		result.Containers = append(result.Containers, hcListMessage{
 			ContainerId: id,
 			CTM:         ctm,
 			State:       convertContainerState(c.State),
@@ -168,19 +155,11 @@ func hcListContainers(client pb.RuntimeServiceClient, opts hcListOptions) error
 			PID:         pid,
 			IP:          IP,
 			MountPoint:  mountPoint,
-		}
-
-		// filter by pid
-		if opts.pid != "" {
-			if opts.pid == pid {
-				result.Containers = append(result.Containers, message)
-				break
-			} else {
-				continue
-			}
-		}
+		})
+	}
 
-		result.Containers = append(result.Containers, message)
+	if handled, err := resolveTemplateOutput(opts.output, opts.template, result); handled {
+		return err
 	}
 
 	switch opts.output {
@@ -193,11 +172,9 @@ func hcListContainers(client pb.RuntimeServiceClient, opts hcListOptions) error
 	default:
 		return fmt.Errorf("unsupported output format %q", opts.output)
 	}
-
-	return nil
 }
 
-func outputAsJSON(obj hcListResult) error {
+func outputAsJSON(obj interface{}) error {
 	jsonBytes, err := json.MarshalIndent(obj, "", "\t")
 	if err != nil {
 		return err
@@ -206,7 +183,7 @@ func outputAsJSON(obj hcListResult) error {
 	return nil
 }
 
-func outputAsYAML(obj hcListResult) error {
+func outputAsYAML(obj interface{}) error {
 	yamlBytes, err := yaml.Marshal(obj)
 	if err != nil {
 		return err
@@ -6,26 +6,14 @@ package main
 
 import (
 	"fmt"
-	"github.com/containers/storage"
 	"github.com/docker/go-units"
-	"github.com/ghodss/yaml"
-	"github.com/golang/protobuf/proto"
-	"github.com/tidwall/gjson"
-	"io/ioutil"
-	"log"
-	"path/filepath"
-	"strings"
+	"strconv"
 	"time"
 
 	"github.com/urfave/cli"
-	"golang.org/x/net/context"
 	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
 )
 
-const (
-	STORAGEROOT = "/etc/containers/storage.conf"
-)
-
 var pidListCommand = cli.Command{
 	Name:  "pids",
 	Usage: "List process of containers",
@@ -51,7 +39,15 @@ var pidListCommand = cli.Command{
 		},
 		cli.StringFlag{
 			Name:  "output, o",
-			Usage: "Output format, One of: json|yaml|table",
+			Usage: "Output format, one of: json|yaml|table|go-template|go-template-file=<path>|jsonpath=<expr>",
+		},
+		cli.StringFlag{
+			Name:  "template",
+			Usage: "Template string used with --output go-template",
+		},
+		cli.StringFlag{
+			Name:  "runtime-backend",
+			Usage: "Container state backend to use, one of: crio|containerd (default: auto-detected from --runtime-endpoint)",
 		},
 	},
 	Action: func(context *cli.Context) error {
@@ -63,11 +59,14 @@ var pidListCommand = cli.Command{
 			return err
 		}
 		opts := pidListOptions{
-			all:        context.Bool("all"),
-			pid:        context.String("pid"),
-			state:      context.String("state"),
-			nameRegexp: context.String("name"),
-			output:     context.String("output"),
+			all:             context.Bool("all"),
+			pid:             context.String("pid"),
+			state:           context.String("state"),
+			nameRegexp:      context.String("name"),
+			output:          context.String("output"),
+			template:        context.String("template"),
+			runtimeBackend:  context.String("runtime-backend"),
+			runtimeEndpoint: context.GlobalString("runtime-endpoint"),
 		}
 
 		if err = pidListContainers(runtimeClient, opts); err != nil {
@@ -79,106 +78,62 @@ var pidListCommand = cli.Command{
 }
 
 func pidListContainers(client pb.RuntimeServiceClient, opts pidListOptions) error {
-	filter := &pb.ContainerFilter{}
-	st := &pb.ContainerStateValue{}
-	if !opts.all {
-		st.State = pb.ContainerState_CONTAINER_RUNNING
-		filter.State = st
-	}
-	if opts.state != "" {
-		st.State = pb.ContainerState_CONTAINER_UNKNOWN
-		switch strings.ToLower(opts.state) {
-		case "created":
-			st.State = pb.ContainerState_CONTAINER_CREATED
-			filter.State = st
-		case "running":
-			st.State = pb.ContainerState_CONTAINER_RUNNING
-			filter.State = st
-		case "exited":
-			st.State = pb.ContainerState_CONTAINER_EXITED
-			filter.State = st
-		case "unknown":
-			st.State = pb.ContainerState_CONTAINER_UNKNOWN
-			filter.State = st
-		default:
-			log.Fatalf("--state should be one of created, running, exited or unknown")
-		}
-	}
-
-	request := &pb.ListContainersRequest{
-		Filter: filter,
-	}
-	r, err := client.ListContainers(context.Background(), request)
+	containers, err := listContainers(client, opts.all, opts.state, opts.nameRegexp)
 	if err != nil {
 		return err
 	}
 
-	switch opts.output {
-	case "json":
-		return outputAsJSON(r)
-	case "yaml":
-		return outputAsYAML(r)
-	case "table", "":
-	// continue; output will be generated after the switch block ends.
-	default:
-		return fmt.Errorf("unsupported output format %q", opts.output)
+	reader, err := newContainerStateReader(opts.runtimeBackend, opts.runtimeEndpoint)
+	if err != nil {
+		return err
 	}
 
-	display := newTableDisplay(20, 1, 3, ' ', 0)
-	display.AddRow([]string{columnContainer, columnCreated, columnState, columnName, columnPID, columnIP, columnMountPoint})
-
-	storageOpts := storage.StoreOptions{}
-	storage.ReloadConfigurationFile(STORAGEROOT, &storageOpts)
-
-	root := filepath.Join(storageOpts.GraphRoot, storageOpts.GraphDriverName+"-containers")
-	for _, c := range r.Containers {
-		if !matchesRegex(opts.nameRegexp, c.Metadata.Name) {
-			continue
-		}
+	result := hcListResult{}
+	for _, c := range containers {
 		createdAt := time.Unix(0, c.CreatedAt)
 		ctm := units.HumanDuration(time.Now().UTC().Sub(createdAt)) + " ago"
 		id := c.Id
-		configRoot := filepath.Join(root, id, "userdata", "config.json")
-		stateRoot := filepath.Join(root, id, "userdata", "state.json")
-		configJson, err := ioutil.ReadFile(configRoot)
+		containerPID, err := reader.PID(id)
 		if err != nil {
 			return err
 		}
-		stateJson, err := ioutil.ReadFile(stateRoot)
+		mountPoint, err := reader.RootfsMountpoint(id)
 		if err != nil {
 			return err
 		}
-		mountPoint := gjson.Get(string(configJson), "root.path").String()
-		pid := gjson.Get(string(stateJson), "pid").String()
-		IP := gjson.Get(string(stateJson), "annotations").Get("io.kubernetes.cri-o.IP").String()
+		IP, err := reader.PodIP(c.PodSandboxId)
+		if err != nil {
+			return err
+		}
+		pid := strconv.Itoa(containerPID)
 
-		display.AddRow([]string{getTruncatedID(id, ""), ctm, convertContainerState(c.State), c.Metadata.Name,
-			pid, IP, mountPoint})
-	}
-	_ = display.Flush()
-	return nil
-}
+		if !matchesPID(opts.pid, pid) {
+			continue
+		}
 
-func outputAsJSON(obj proto.Message) error {
-	marshaledJSON, err := protobufObjectToJSON(obj)
-	if err != nil {
-		return err
+		result.Containers = append(result.Containers, hcListMessage{
+			ContainerId: getTruncatedID(id, ""),
+			CTM:         ctm,
+			State:       convertContainerState(c.State),
+			Name:        c.Metadata.Name,
+			PID:         pid,
+			IP:          IP,
+			MountPoint:  mountPoint,
+		})
 	}
 
-	fmt.Println(marshaledJSON)
-	return nil
-}
-
-func outputAsYAML(obj proto.Message) error {
-	marshaledJSON, err := protobufObjectToJSON(obj)
-	if err != nil {
-		return err
-	}
-	marshaledYAML, err := yaml.JSONToYAML([]byte(marshaledJSON))
-	if err != nil {
+	if handled, err := resolveTemplateOutput(opts.output, opts.template, result); handled {
 		return err
 	}
 
-	fmt.Println(string(marshaledYAML))
-	return nil
+	switch opts.output {
+	case "json":
+		return outputAsJSON(result)
+	case "yaml":
+		return outputAsYAML(result)
+	case "table", "":
+		return outputAsTable(result)
+	default:
+		return fmt.Errorf("unsupported output format %q", opts.output)
+	}
 }
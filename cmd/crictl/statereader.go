@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/containers/storage"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	runtimeBackendCRIO       = "crio"
+	runtimeBackendContainerd = "containerd"
+)
+
+// ContainerStateReader looks up the host-level facts about a container that
+// the CRI ListContainers/ListContainerStats RPCs don't expose: its PID, the
+// mountpoint of its rootfs, and the IP assigned to its pod network namespace.
+// CRI-O and containerd record this information completely differently, so
+// each runtime gets its own implementation.
+type ContainerStateReader interface {
+	PID(id string) (int, error)
+	RootfsMountpoint(id string) (string, error)
+	// PodIP takes a pod sandbox ID, not an app container's own ID: the pod
+	// network namespace (and any CNI result) belongs to the sandbox, and app
+	// containers only share it.
+	PodIP(podSandboxID string) (string, error)
+}
+
+// newContainerStateReader builds the ContainerStateReader for backend. An
+// empty backend is auto-detected from runtimeEndpoint, e.g.
+// unix:///run/containerd/containerd.sock selects containerd while anything
+// else (crio.sock, ...) falls back to the CRI-O on-disk layout.
+func newContainerStateReader(backend, runtimeEndpoint string) (ContainerStateReader, error) {
+	if backend == "" {
+		backend = detectRuntimeBackend(runtimeEndpoint)
+	}
+	switch backend {
+	case runtimeBackendCRIO:
+		return newCRIOStateReader()
+	case runtimeBackendContainerd:
+		return newContainerdStateReader(), nil
+	default:
+		return nil, fmt.Errorf("unknown --runtime-backend %q (want %q or %q)", backend, runtimeBackendCRIO, runtimeBackendContainerd)
+	}
+}
+
+func detectRuntimeBackend(runtimeEndpoint string) string {
+	if strings.Contains(runtimeEndpoint, "containerd") {
+		return runtimeBackendContainerd
+	}
+	return runtimeBackendCRIO
+}
+
+// crioStateReader delegates to the containers/storage Store that CRI-O
+// itself uses, instead of reimplementing storage.conf parsing and graph
+// driver path layout. Only the PID and pod IP still come from the
+// userdata/state.json conmon writes into the container's run directory;
+// containers/storage has no notion of either.
+type crioStateReader struct {
+	store storage.Store
+}
+
+func newCRIOStateReader() (*crioStateReader, error) {
+	// A non-root UID means the store lives under the user's own runtime/home
+	// dirs instead of /etc/containers/storage.conf and /var/lib/containers,
+	// so a rootless user never needs the system-wide config to exist.
+	rootless := os.Getuid() != 0
+	rootlessUID := os.Getuid()
+	storeOpts, err := storage.DefaultStoreOptions(rootless, rootlessUID)
+	if err != nil {
+		return nil, err
+	}
+	store, err := storage.GetStore(storeOpts)
+	if err != nil {
+		return nil, err
+	}
+	return &crioStateReader{store: store}, nil
+}
+
+func (r *crioStateReader) stateJSON(id string) ([]byte, error) {
+	runDir, err := r.store.ContainerRunDirectory(id)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadFile(filepath.Join(runDir, "state.json"))
+}
+
+func (r *crioStateReader) PID(id string) (int, error) {
+	stateJSON, err := r.stateJSON(id)
+	if err != nil {
+		return 0, err
+	}
+	return int(gjson.GetBytes(stateJSON, "pid").Int()), nil
+}
+
+func (r *crioStateReader) RootfsMountpoint(id string) (string, error) {
+	if _, err := r.store.Container(id); err != nil {
+		return "", err
+	}
+	// Mount is refcounted; since we only need the path and CRI-O already
+	// keeps the container mounted for its lifetime, undo our own mount
+	// immediately so repeated `hc ls`/`hc pids` calls don't leak references.
+	mountPoint, err := r.store.Mount(id, "")
+	if err != nil {
+		return "", err
+	}
+	if _, err := r.store.Unmount(id, false); err != nil {
+		return "", err
+	}
+	return mountPoint, nil
+}
+
+func (r *crioStateReader) PodIP(id string) (string, error) {
+	stateJSON, err := r.stateJSON(id)
+	if err != nil {
+		return "", err
+	}
+	return gjson.GetBytes(stateJSON, `annotations.io\.kubernetes\.cri-o\.IP`).String(), nil
+}
+
+// containerdStateReader reads the state the runc v2 shim and CNI leave on
+// disk, rather than dialing the containerd tasks service, so `crictl hc`
+// keeps working with only the CRI socket configured.
+type containerdStateReader struct {
+	stateRoot     string
+	cniResultsDir string
+}
+
+func newContainerdStateReader() *containerdStateReader {
+	return &containerdStateReader{
+		stateRoot:     "/run/containerd/io.containerd.runtime.v2.task",
+		cniResultsDir: "/var/lib/cni/results",
+	}
+}
+
+// taskDir returns the shim's per-task directory for id. Containerd namespaces
+// tasks (CRI-managed ones live under the "k8s.io" namespace), so we glob for
+// the id rather than hardcoding the namespace.
+func (r *containerdStateReader) taskDir(id string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(r.stateRoot, "*", id))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no containerd task state found for %s under %s", id, r.stateRoot)
+	}
+	return matches[0], nil
+}
+
+func (r *containerdStateReader) PID(id string) (int, error) {
+	dir, err := r.taskDir(id)
+	if err != nil {
+		return 0, err
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, "init.pid"))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+func (r *containerdStateReader) RootfsMountpoint(id string) (string, error) {
+	dir, err := r.taskDir(id)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "rootfs"), nil
+}
+
+// PodIP matches the CNI result file containerd's CNI plugin leaves behind
+// for id and pulls the first allocated IP out of it. CNI only runs once per
+// pod sandbox, so callers must pass the sandbox ID, not an app container's
+// own ID — app containers share their sandbox's netns and never get a CNI
+// result file of their own. A missing result is not an error: it just means
+// the IP isn't known yet, so the row's IP is left blank, matching the
+// CRI-O path's tolerant gjson-empty-string behavior.
+func (r *containerdStateReader) PodIP(id string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(r.cniResultsDir, "*-"+id+"-*.json"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", nil
+	}
+	b, err := ioutil.ReadFile(matches[0])
+	if err != nil {
+		return "", err
+	}
+	if ip := gjson.GetBytes(b, "ips.0.address").String(); ip != "" {
+		return strings.SplitN(ip, "/", 2)[0], nil
+	}
+	return strings.SplitN(gjson.GetBytes(b, "ip4.ip").String(), "/", 2)[0], nil
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/go-units"
+	"github.com/urfave/cli"
+	"golang.org/x/net/context"
+	pb "k8s.io/cri-api/pkg/apis/runtime/v1alpha2"
+)
+
+type hcStatsOptions struct {
+	hcListOptions
+	interval time.Duration
+	watch    bool
+	noStream bool
+}
+
+type hcStatsMessage struct {
+	ContainerId string
+	Name        string
+	State       string
+	CPUPercent  string
+	Memory      string
+	PIDs        string
+	NetworkRx   string
+	NetworkTx   string
+}
+
+type hcStatsResult struct {
+	Containers []hcStatsMessage
+}
+
+var hcStatsCommand = cli.Command{
+	Name:  "stats",
+	Usage: "Show a live stream of container CPU, memory, PIDs and network usage",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "pid, p",
+			Value: "",
+			Usage: "Filter by pid",
+		},
+		cli.StringFlag{
+			Name:  "state, s",
+			Value: "",
+			Usage: "Filter by container state",
+		},
+		cli.StringFlag{
+			Name:  "name, n",
+			Value: "",
+			Usage: "filter by container name regular expression pattern",
+		},
+		cli.BoolFlag{
+			Name:  "all, a",
+			Usage: "Show all containers",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "Output format, One of: json|yaml|table",
+		},
+		cli.StringFlag{
+			Name:  "runtime-backend",
+			Usage: "Container state backend to use, one of: crio|containerd (default: auto-detected from --runtime-endpoint)",
+		},
+		cli.DurationFlag{
+			Name:  "interval, i",
+			Value: time.Second,
+			Usage: "Refresh interval when --watch is set",
+		},
+		cli.BoolFlag{
+			Name:  "watch, w",
+			Usage: "Redraw the table in place every --interval instead of exiting after one frame",
+		},
+		cli.BoolFlag{
+			Name:  "no-stream",
+			Usage: "Print a single JSON/YAML/table snapshot and exit, ignoring --watch",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		var err error
+		if err = getRuntimeClient(context); err != nil {
+			return err
+		}
+		if err = getImageClient(context); err != nil {
+			return err
+		}
+		opts := hcStatsOptions{
+			hcListOptions: hcListOptions{
+				all:             context.Bool("all"),
+				pid:             context.String("pid"),
+				state:           context.String("state"),
+				nameRegexp:      context.String("name"),
+				output:          context.String("output"),
+				runtimeBackend:  context.String("runtime-backend"),
+				runtimeEndpoint: context.GlobalString("runtime-endpoint"),
+			},
+			interval: context.Duration("interval"),
+			watch:    context.Bool("watch"),
+			noStream: context.Bool("no-stream"),
+		}
+
+		if err = hcStatsContainers(runtimeClient, opts); err != nil {
+			return fmt.Errorf("getting container stats failed: %v", err)
+		}
+
+		return nil
+	},
+}
+
+func hcStatsContainers(client pb.RuntimeServiceClient, opts hcStatsOptions) error {
+	reader, err := newContainerStateReader(opts.runtimeBackend, opts.runtimeEndpoint)
+	if err != nil {
+		return err
+	}
+
+	for {
+		containers, err := listContainers(client, opts.all, opts.state, opts.nameRegexp)
+		if err != nil {
+			return err
+		}
+
+		messages := make([]hcStatsMessage, 0, len(containers))
+		for _, c := range containers {
+			pid, err := reader.PID(c.Id)
+			if err != nil {
+				return err
+			}
+			if !matchesPID(opts.pid, strconv.Itoa(pid)) {
+				continue
+			}
+			msg, err := statsMessageFor(client, pid, c)
+			if err != nil {
+				return err
+			}
+			messages = append(messages, msg)
+		}
+
+		if opts.watch && !opts.noStream {
+			clearScreen()
+		}
+
+		switch opts.output {
+		case "json":
+			if err := outputAsJSON(hcStatsResult{Containers: messages}); err != nil {
+				return err
+			}
+		case "yaml":
+			if err := outputAsYAML(hcStatsResult{Containers: messages}); err != nil {
+				return err
+			}
+		case "table", "":
+			outputStatsAsTable(messages)
+		default:
+			return fmt.Errorf("unsupported output format %q", opts.output)
+		}
+
+		if opts.noStream || !opts.watch {
+			return nil
+		}
+		time.Sleep(opts.interval)
+	}
+}
+
+// statsMessageFor takes two CPU samples a short interval apart so it can
+// report a percentage rather than the raw (and otherwise meaningless without
+// a baseline) cumulative core-nanosecond counter the CRI returns.
+func statsMessageFor(client pb.RuntimeServiceClient, pid int, c *pb.Container) (hcStatsMessage, error) {
+	const sampleGap = 200 * time.Millisecond
+
+	first, err := client.ContainerStats(context.Background(), &pb.ContainerStatsRequest{ContainerId: c.Id})
+	if err != nil {
+		return hcStatsMessage{}, err
+	}
+	time.Sleep(sampleGap)
+	second, err := client.ContainerStats(context.Background(), &pb.ContainerStatsRequest{ContainerId: c.Id})
+	if err != nil {
+		return hcStatsMessage{}, err
+	}
+
+	cpuPercent := "-"
+	if s1, s2 := first.GetStats().GetCpu(), second.GetStats().GetCpu(); s1 != nil && s2 != nil {
+		deltaNanos := s2.UsageCoreNanoSeconds.GetValue() - s1.UsageCoreNanoSeconds.GetValue()
+		deltaTime := time.Duration(s2.Timestamp - s1.Timestamp)
+		if deltaTime > 0 {
+			pct := float64(deltaNanos) / float64(deltaTime) / float64(runtime.NumCPU()) * 100
+			cpuPercent = fmt.Sprintf("%.2f%%", pct)
+		}
+	}
+
+	memory := "-"
+	if m := second.GetStats().GetMemory(); m != nil {
+		memory = units.BytesSize(float64(m.WorkingSetBytes.GetValue()))
+	}
+
+	pids, rx, tx := "-", "-", "-"
+	if n, err := countTasks(pid); err == nil {
+		pids = strconv.Itoa(n)
+	}
+	if rxBytes, txBytes, err := netDevTotals(pid); err == nil {
+		rx, tx = units.BytesSize(float64(rxBytes)), units.BytesSize(float64(txBytes))
+	}
+
+	return hcStatsMessage{
+		ContainerId: getTruncatedID(c.Id, ""),
+		Name:        c.Metadata.Name,
+		State:       convertContainerState(c.State),
+		CPUPercent:  cpuPercent,
+		Memory:      memory,
+		PIDs:        pids,
+		NetworkRx:   rx,
+		NetworkTx:   tx,
+	}, nil
+}
+
+// countTasks returns the number of threads of the process pid, read from
+// /proc/<pid>/task. Only that one process (the container's own pid) is
+// counted; other processes running in the same container are not.
+func countTasks(pid int) (int, error) {
+	entries, err := ioutil.ReadDir(fmt.Sprintf("/proc/%d/task", pid))
+	if err != nil {
+		return 0, err
+	}
+	return len(entries), nil
+}
+
+// netDevTotals sums the rx/tx byte counters of every interface except lo
+// visible in pid's network namespace via /proc/<pid>/net/dev, which works for any
+// runtime without needing a CNI-specific integration.
+func netDevTotals(pid int) (rx, tx uint64, err error) {
+	f, err := os.Open(fmt.Sprintf("/proc/%d/net/dev", pid))
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for i := 0; scanner.Scan(); i++ {
+		if i < 2 {
+			continue // header lines
+		}
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 {
+			continue
+		}
+		iface := strings.TrimSuffix(fields[0], ":")
+		if iface == "lo" {
+			continue
+		}
+		rxBytes, _ := strconv.ParseUint(fields[1], 10, 64)
+		txBytes, _ := strconv.ParseUint(fields[9], 10, 64)
+		rx += rxBytes
+		tx += txBytes
+	}
+	return rx, tx, scanner.Err()
+}
+
+func outputStatsAsTable(messages []hcStatsMessage) {
+	display := newTableDisplay(20, 1, 3, ' ', 0)
+	display.AddRow([]string{columnContainer, columnName, columnState, "CPU %", "MEM", "PIDS", "RX", "TX"})
+	for _, m := range messages {
+		display.AddRow([]string{m.ContainerId, m.Name, m.State, m.CPUPercent, m.Memory, m.PIDs, m.NetworkRx, m.NetworkTx})
+	}
+	_ = display.Flush()
+}
+
+// clearScreen resets the cursor and wipes the terminal for --watch redraws;
+// on a non-TTY stdout (e.g. piped to a file) it appends a blank line between
+// frames instead so each frame stays readable.
+func clearScreen() {
+	if isTerminal(os.Stdout) {
+		fmt.Print("\033[H\033[2J")
+		return
+	}
+	fmt.Println()
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/template"
+
+	"k8s.io/client-go/util/jsonpath"
+)
+
+// outputAsGoTemplate renders data with the given text/template source,
+// mirroring kubectl/podman's -o go-template.
+func outputAsGoTemplate(tmplText string, data interface{}) error {
+	tmpl, err := template.New("hc").Parse(tmplText)
+	if err != nil {
+		return fmt.Errorf("parsing template: %v", err)
+	}
+	return tmpl.Execute(os.Stdout, data)
+}
+
+// outputAsJSONPath renders data with a kubectl-style JSONPath expression,
+// e.g. jsonpath={range .Containers[*]}{.ContainerId}{"\n"}{end}.
+func outputAsJSONPath(expr string, data interface{}) error {
+	jp := jsonpath.New("hc")
+	if err := jp.Parse(expr); err != nil {
+		return fmt.Errorf("parsing jsonpath: %v", err)
+	}
+	return jp.Execute(os.Stdout, data)
+}
+
+// resolveTemplateOutput interprets the --output value for the
+// template-driven formats:
+//   - "go-template", rendered using the --template flag's contents
+//   - "go-template-file=<path>", the template is read from path
+//   - "jsonpath=<expr>"
+//
+// handled is false when output names none of these, so the caller can fall
+// through to its own json/yaml/table handling.
+func resolveTemplateOutput(output, tmplFlag string, data interface{}) (handled bool, err error) {
+	switch {
+	case output == "go-template":
+		return true, outputAsGoTemplate(tmplFlag, data)
+	case strings.HasPrefix(output, "go-template-file="):
+		path := strings.TrimPrefix(output, "go-template-file=")
+		b, err := ioutil.ReadFile(path)
+		if err != nil {
+			return true, err
+		}
+		return true, outputAsGoTemplate(string(b), data)
+	case strings.HasPrefix(output, "jsonpath="):
+		return true, outputAsJSONPath(strings.TrimPrefix(output, "jsonpath="), data)
+	default:
+		return false, nil
+	}
+}